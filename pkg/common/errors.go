@@ -0,0 +1,88 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrorCode classifies a RunError so callers (and remote coordinators) can
+// branch on failure kind without parsing error strings.
+type ErrorCode string
+
+const (
+	// JobFailed indicates a job's steps completed but reported failure.
+	JobFailed ErrorCode = "JobFailed"
+	// ContainerPullFailed indicates pulling a container image failed.
+	ContainerPullFailed ErrorCode = "ContainerPullFailed"
+	// NetworkCreateFailed indicates creating, inspecting or removing a job network failed.
+	NetworkCreateFailed ErrorCode = "NetworkCreateFailed"
+	// MatrixInvalid indicates a job's strategy matrix could not be evaluated.
+	MatrixInvalid ErrorCode = "MatrixInvalid"
+)
+
+// RunError is the structured error surface for job and plan failures. It
+// carries enough context (which job, which stage, which attempt, and why)
+// for a remote coordinator to act on a failure without substring-matching
+// an error string, and marshals to JSON so a RemoteRunner can ship it back
+// over the wire.
+type RunError struct {
+	Code       ErrorCode
+	JobID      string
+	Stage      int
+	Attempt    int
+	Cause      error
+	HTTPStatus int
+}
+
+func (e *RunError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s", e.Code, e.Cause)
+	}
+	return string(e.Code)
+}
+
+// Unwrap makes RunError compatible with errors.Is/errors.As over its cause.
+func (e *RunError) Unwrap() error {
+	return e.Cause
+}
+
+type runErrorJSON struct {
+	Code       ErrorCode `json:"code"`
+	JobID      string    `json:"job_id,omitempty"`
+	Stage      int       `json:"stage,omitempty"`
+	Attempt    int       `json:"attempt,omitempty"`
+	Cause      string    `json:"cause,omitempty"`
+	HTTPStatus int       `json:"http_status,omitempty"`
+}
+
+// MarshalJSON flattens Cause to its error string, since remote coordinators
+// only need the message, not a Go error value.
+func (e *RunError) MarshalJSON() ([]byte, error) {
+	out := runErrorJSON{
+		Code:       e.Code,
+		JobID:      e.JobID,
+		Stage:      e.Stage,
+		Attempt:    e.Attempt,
+		HTTPStatus: e.HTTPStatus,
+	}
+	if e.Cause != nil {
+		out.Cause = e.Cause.Error()
+	}
+	return json.Marshal(out)
+}
+
+// IsRetryable reports whether err (or anything it wraps) is a RunError whose
+// Code represents a transient failure worth retrying.
+func IsRetryable(err error) bool {
+	var runErr *RunError
+	if !errors.As(err, &runErr) {
+		return false
+	}
+	switch runErr.Code {
+	case JobFailed, ContainerPullFailed, NetworkCreateFailed:
+		return true
+	default:
+		return false
+	}
+}
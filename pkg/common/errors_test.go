@@ -0,0 +1,63 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRunErrorMarshalJSON(t *testing.T) {
+	err := &RunError{
+		Code:    NetworkCreateFailed,
+		JobID:   "build",
+		Stage:   2,
+		Attempt: 1,
+		Cause:   errors.New("boom"),
+	}
+
+	data, marshalErr := json.Marshal(err)
+	if marshalErr != nil {
+		t.Fatalf("Marshal returned error: %v", marshalErr)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+
+	if out["code"] != string(NetworkCreateFailed) {
+		t.Errorf("code = %v, want %v", out["code"], NetworkCreateFailed)
+	}
+	if out["job_id"] != "build" {
+		t.Errorf("job_id = %v, want build", out["job_id"])
+	}
+	if out["stage"] != float64(2) {
+		t.Errorf("stage = %v, want 2", out["stage"])
+	}
+	if out["cause"] != "boom" {
+		t.Errorf("cause = %v, want boom", out["cause"])
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	kases := []struct {
+		name   string
+		err    error
+		result bool
+	}{
+		{"plain error is not retryable", errors.New("boom"), false},
+		{"JobFailed is retryable", &RunError{Code: JobFailed}, true},
+		{"ContainerPullFailed is retryable", &RunError{Code: ContainerPullFailed}, true},
+		{"NetworkCreateFailed is retryable", &RunError{Code: NetworkCreateFailed}, true},
+		{"MatrixInvalid is not retryable", &RunError{Code: MatrixInvalid}, false},
+		{"wrapped RunError is retryable", fmt.Errorf("context: %w", &RunError{Code: JobFailed}), true},
+	}
+	for _, kase := range kases {
+		t.Run(kase.name, func(t *testing.T) {
+			if got := IsRetryable(kase.err); got != kase.result {
+				t.Errorf("IsRetryable(%v) = %v, want %v", kase.err, got, kase.result)
+			}
+		})
+	}
+}
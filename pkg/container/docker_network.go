@@ -6,29 +6,116 @@ import (
 	"context"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
 	"github.com/nektos/act/pkg/common"
 )
 
-func NewDockerNetworkCreateExecutor(name string, inheritDriverOpts []string) common.Executor {
+// NetworkCreateIPAMConfig describes a single IPAM pool for a user-defined network.
+type NetworkCreateIPAMConfig struct {
+	Subnet     string
+	Gateway    string
+	IPRange    string
+	AuxAddress map[string]string
+}
+
+// NetworkCreateOptions configures NewDockerNetworkCreateExecutor beyond the
+// default single-host bridge network.
+type NetworkCreateOptions struct {
+	// Driver is the network driver to use, e.g. "bridge", "overlay", "macvlan", "ipvlan".
+	// Defaults to "bridge" when empty.
+	Driver string
+	// Scope is the network's scope, e.g. "local", "swarm". Defaults to "local".
+	// When set to "swarm" and a network with the same name already exists,
+	// the executor short-circuits rather than re-creating it.
+	Scope string
+	// Options are driver-specific options, e.g. com.docker.network.driver.mtu or parent.
+	Options map[string]string
+	// IPAM is the optional IP address management config for the network.
+	IPAM *NetworkCreateIPAMConfig
+	// Attachable allows standalone containers to attach to the network (required for overlay).
+	Attachable bool
+	// Internal restricts external access to the network.
+	Internal bool
+	// EnableIPv6 enables IPv6 networking on this network.
+	EnableIPv6 bool
+	// InheritDriverOpts copies the named options from the host's "bridge" network
+	// when Driver is "bridge" (or empty).
+	InheritDriverOpts []string
+}
+
+// buildNetworkCreate computes the effective driver and scope (applying the
+// "bridge"/"local" defaults) and the types.NetworkCreate payload for opts,
+// including its Options and IPAM config. It touches nothing on the docker
+// client, so NewDockerNetworkCreateExecutor's defaulting can be covered by a
+// test without a real (or mocked) docker client.
+func buildNetworkCreate(opts NetworkCreateOptions) (driver, scope string, createOpts types.NetworkCreate) {
+	scope = opts.Scope
+	if scope == "" {
+		scope = "local"
+	}
+
+	driver = opts.Driver
+	if driver == "" {
+		driver = "bridge"
+	}
+
+	createOpts = types.NetworkCreate{
+		Driver:     driver,
+		Scope:      scope,
+		Attachable: opts.Attachable,
+		Internal:   opts.Internal,
+		EnableIPv6: opts.EnableIPv6,
+	}
+
+	if len(opts.Options) > 0 {
+		createOpts.Options = make(map[string]string, len(opts.Options))
+		for k, v := range opts.Options {
+			createOpts.Options[k] = v
+		}
+	}
+
+	if opts.IPAM != nil {
+		createOpts.IPAM = &network.IPAM{
+			Config: []network.IPAMConfig{
+				{
+					Subnet:     opts.IPAM.Subnet,
+					Gateway:    opts.IPAM.Gateway,
+					IPRange:    opts.IPAM.IPRange,
+					AuxAddress: opts.IPAM.AuxAddress,
+				},
+			},
+		}
+	}
+
+	return driver, scope, createOpts
+}
+
+func NewDockerNetworkCreateExecutor(name string, opts NetworkCreateOptions) common.Executor {
 	return func(ctx context.Context) error {
 		cli, err := GetDockerClient(ctx)
 		if err != nil {
-			return err
+			return &common.RunError{Code: common.NetworkCreateFailed, Cause: err}
 		}
 
-		createOpts := types.NetworkCreate{
-			Driver: "bridge",
-			Scope:  "local",
+		driver, scope, createOpts := buildNetworkCreate(opts)
+
+		if scope == "swarm" {
+			if existing, err := cli.NetworkInspect(ctx, name, types.NetworkInspectOptions{Scope: scope}); err == nil {
+				_ = existing
+				return nil
+			}
 		}
 
-		if len(inheritDriverOpts) > 0 {
-			network, err := cli.NetworkInspect(ctx, "bridge", types.NetworkInspectOptions{Scope: "local"})
+		if driver == "bridge" && len(opts.InheritDriverOpts) > 0 {
+			bridge, err := cli.NetworkInspect(ctx, "bridge", types.NetworkInspectOptions{Scope: "local"})
 			if err != nil {
-				return err
+				return &common.RunError{Code: common.NetworkCreateFailed, Cause: err}
+			}
+			if createOpts.Options == nil {
+				createOpts.Options = make(map[string]string, len(opts.InheritDriverOpts))
 			}
-			createOpts.Options = make(map[string]string, len(inheritDriverOpts))
-			for _, optKey := range inheritDriverOpts {
-				if val, ok := network.Options[optKey]; ok {
+			for _, optKey := range opts.InheritDriverOpts {
+				if val, ok := bridge.Options[optKey]; ok {
 					createOpts.Options[optKey] = val
 				}
 			}
@@ -36,7 +123,7 @@ func NewDockerNetworkCreateExecutor(name string, inheritDriverOpts []string) com
 
 		_, err = cli.NetworkCreate(ctx, name, createOpts)
 		if err != nil {
-			return err
+			return &common.RunError{Code: common.NetworkCreateFailed, Cause: err}
 		}
 
 		return nil
@@ -47,9 +134,13 @@ func NewDockerNetworkRemoveExecutor(name string) common.Executor {
 	return func(ctx context.Context) error {
 		cli, err := GetDockerClient(ctx)
 		if err != nil {
-			return err
+			return &common.RunError{Code: common.NetworkCreateFailed, Cause: err}
 		}
 
-		return cli.NetworkRemove(ctx, name)
+		if err := cli.NetworkRemove(ctx, name); err != nil {
+			return &common.RunError{Code: common.NetworkCreateFailed, Cause: err}
+		}
+
+		return nil
 	}
 }
@@ -0,0 +1,56 @@
+//go:build !(WITHOUT_DOCKER || !(linux || darwin || windows))
+
+package container
+
+import "testing"
+
+func TestBuildNetworkCreateDefaults(t *testing.T) {
+	driver, scope, createOpts := buildNetworkCreate(NetworkCreateOptions{})
+
+	if driver != "bridge" {
+		t.Errorf("driver = %q, want bridge", driver)
+	}
+	if scope != "local" {
+		t.Errorf("scope = %q, want local", scope)
+	}
+	if createOpts.Options != nil {
+		t.Errorf("Options = %v, want nil", createOpts.Options)
+	}
+	if createOpts.IPAM != nil {
+		t.Errorf("IPAM = %v, want nil", createOpts.IPAM)
+	}
+}
+
+func TestBuildNetworkCreateOverrides(t *testing.T) {
+	opts := NetworkCreateOptions{
+		Driver:     "overlay",
+		Scope:      "swarm",
+		Options:    map[string]string{"com.docker.network.driver.mtu": "1450"},
+		Attachable: true,
+		IPAM: &NetworkCreateIPAMConfig{
+			Subnet:  "10.0.0.0/24",
+			Gateway: "10.0.0.1",
+		},
+	}
+
+	driver, scope, createOpts := buildNetworkCreate(opts)
+
+	if driver != "overlay" {
+		t.Errorf("driver = %q, want overlay", driver)
+	}
+	if scope != "swarm" {
+		t.Errorf("scope = %q, want swarm", scope)
+	}
+	if !createOpts.Attachable {
+		t.Errorf("Attachable = false, want true")
+	}
+	if createOpts.Options["com.docker.network.driver.mtu"] != "1450" {
+		t.Errorf("Options[mtu] = %q, want 1450", createOpts.Options["com.docker.network.driver.mtu"])
+	}
+	if createOpts.IPAM == nil || len(createOpts.IPAM.Config) != 1 {
+		t.Fatalf("IPAM = %v, want one config entry", createOpts.IPAM)
+	}
+	if createOpts.IPAM.Config[0].Subnet != "10.0.0.0/24" || createOpts.IPAM.Config[0].Gateway != "10.0.0.1" {
+		t.Errorf("IPAM config = %+v, want subnet/gateway as given", createOpts.IPAM.Config[0])
+	}
+}
@@ -0,0 +1,26 @@
+package runner
+
+import "testing"
+
+func TestResolveMaxParallel(t *testing.T) {
+	kases := []struct {
+		name          string
+		configuredMax int
+		strategyMax   int
+		matrixCount   int
+		result        int
+	}{
+		{"defaults to 4 when nothing is configured", 0, 0, 10, 4},
+		{"Config.MaxParallelMatrix overrides the default", 2, 0, 10, 2},
+		{"strategy.max-parallel overrides Config.MaxParallelMatrix", 2, 6, 10, 6},
+		{"zero strategy.max-parallel does not override", 2, 0, 10, 2},
+		{"never exceeds the matrix count", 8, 0, 3, 3},
+	}
+	for _, kase := range kases {
+		t.Run(kase.name, func(t *testing.T) {
+			if got := resolveMaxParallel(kase.configuredMax, kase.strategyMax, kase.matrixCount); got != kase.result {
+				t.Errorf("resolveMaxParallel(%d, %d, %d) = %d, want %d", kase.configuredMax, kase.strategyMax, kase.matrixCount, got, kase.result)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+)
+
+// RemoteRunner is a Runner that does not execute jobs of its own accord.
+// Instead it behaves like a Drone/Woodpecker-style agent: it repeatedly
+// pulls one job at a time from a coordinator, runs it locally through the
+// existing NewPlanExecutor, and streams logs and state back over client.
+type RemoteRunner struct {
+	config *Config
+	local  Runner
+	client CoordinatorClient
+}
+
+// NewRemoteRunner creates a RemoteRunner that dispatches jobs pulled from
+// client through a local runnerImpl configured with runnerConfig.
+// runnerConfig.CoordinatorURL must be set; it is not dialed here (client
+// already wraps the transport), but its presence is required so a
+// misconfigured agent fails fast instead of polling nothing.
+func NewRemoteRunner(runnerConfig *Config, client CoordinatorClient) (*RemoteRunner, error) {
+	if runnerConfig.CoordinatorURL == "" {
+		return nil, fmt.Errorf("runner: CoordinatorURL must be set to run a RemoteRunner")
+	}
+
+	local, err := New(runnerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RemoteRunner{
+		config: runnerConfig,
+		local:  local,
+		client: client,
+	}, nil
+}
+
+// NewPlanExecutor satisfies the Runner interface by running plan locally,
+// the same way Run does for each job it pulls from the coordinator. This
+// keeps RemoteRunner a drop-in Runner for callers (e.g. Restart) that
+// already hold a resolved plan.
+func (r *RemoteRunner) NewPlanExecutor(plan *model.Plan) common.Executor {
+	return r.local.NewPlanExecutor(plan)
+}
+
+// Restart forwards to the local runner, so a coordinator can request a
+// single-job rebuild the same way it would against a local Runner.
+func (r *RemoteRunner) Restart(plan *model.Plan, jobID string) common.Executor {
+	return r.local.Restart(plan, jobID)
+}
+
+// Run polls the coordinator for jobs matching Config.AgentLabels and
+// executes them locally, up to Config.MaxProcs at a time (one at a time when
+// MaxProcs is 0), until ctx is cancelled. Run returns only on ctx
+// cancellation: an error from Next is logged and the poll is retried after
+// Config.Backoff (5s when unset). Every coordinator RPC issued by Run (and
+// by the per-job goroutines it spawns) carries Config.AgentToken so a
+// CoordinatorClient implementation can authenticate outbound calls via
+// AgentTokenFromContext.
+func (r *RemoteRunner) Run(ctx context.Context) error {
+	ctx = withAgentToken(ctx, r.config.AgentToken)
+	filter := Filter{Labels: r.config.AgentLabels}
+
+	maxProcs := r.config.MaxProcs
+	if maxProcs <= 0 {
+		maxProcs = 1
+	}
+	sem := make(chan struct{}, maxProcs)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case sem <- struct{}{}:
+		}
+
+		job, err := r.client.Next(ctx, filter)
+		if err != nil {
+			<-sem
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Errorf("failed to pull next job from coordinator: %v", err)
+			if !r.sleepBackoff(ctx) {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		go func(job *Job) {
+			defer func() { <-sem }()
+			r.runJob(ctx, job)
+		}(job)
+	}
+}
+
+func (r *RemoteRunner) runJob(ctx context.Context, job *Job) {
+	jobCtx, cancel := context.WithCancel(ctx)
+
+	cancelled, err := r.client.Wait(ctx, job.ID)
+	if err != nil {
+		log.Errorf("failed to watch job '%s' for cancellation: %v", job.ID, err)
+	} else {
+		go func() {
+			select {
+			case <-cancelled:
+				cancel()
+			case <-jobCtx.Done():
+			}
+		}()
+	}
+
+	stopHeartbeat := r.startHeartbeat(jobCtx, job.ID)
+	stopLogStreaming := r.startLogStreaming(jobCtx, job.ID)
+
+	result := "success"
+	if err := r.local.NewPlanExecutor(job.Plan)(jobCtx); err != nil {
+		result = "failure"
+		log.Errorf("job '%s' failed: %v", job.ID, err)
+	}
+	jobDone := jobCtx.Err() != nil
+	if jobDone {
+		result = "cancelled"
+	}
+
+	// Tear down the cancellation watcher and log/heartbeat goroutines before
+	// reporting state: they all key off jobCtx, so it must be cancelled
+	// first or stopHeartbeat/stopLogStreaming (which wait for it) block
+	// forever.
+	cancel()
+	stopHeartbeat()
+	stopLogStreaming()
+
+	if err := r.client.Update(ctx, State{JobID: job.ID, Result: result}); err != nil {
+		log.Errorf("failed to report state for job '%s': %v", job.ID, err)
+	}
+}
+
+func (r *RemoteRunner) startHeartbeat(ctx context.Context, jobID string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				close(done)
+				return
+			case <-ticker.C:
+				if err := r.client.Extend(ctx, jobID); err != nil {
+					log.Errorf("failed to extend lease for job '%s': %v", jobID, err)
+				}
+			}
+		}
+	}()
+	return func() { <-done }
+}
+
+// startLogStreaming forwards every log line produced while running jobID to
+// client.Log, by attaching a hook to the standard logrus logger. It assumes
+// WithJobLogger tags its entries with "jobID"/"stepID" fields on that same
+// default logger instance (log.StandardLogger()) rather than a dedicated or
+// child logger; TestRemoteLogHookForwardsTaggedEntries covers the hook's
+// own filtering/forwarding against that assumption, but WithJobLogger isn't
+// in this tree to verify the assumption itself holds end-to-end. It returns
+// a func that blocks until ctx is cancelled and then detaches the hook
+// again; the hook keeps forwarding lines until then.
+func (r *RemoteRunner) startLogStreaming(ctx context.Context, jobID string) func() {
+	hook := &remoteLogHook{ctx: ctx, client: r.client, jobID: jobID}
+	addLogHook(hook)
+	return func() {
+		<-ctx.Done()
+		removeLogHook(hook)
+	}
+}
+
+// remoteLogHook is a logrus.Hook that forwards log entries tagged with
+// jobID to the coordinator via client.Log.
+type remoteLogHook struct {
+	ctx    context.Context
+	client CoordinatorClient
+	jobID  string
+}
+
+func (h *remoteLogHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *remoteLogHook) Fire(entry *log.Entry) error {
+	if entryJobID, _ := entry.Data["jobID"].(string); entryJobID != h.jobID {
+		return nil
+	}
+	stepID, _ := entry.Data["stepID"].(string)
+	return h.client.Log(h.ctx, h.jobID, Line{JobID: h.jobID, StepID: stepID, Line: entry.Message})
+}
+
+// logHooksMu serializes registration of remoteLogHooks against the shared
+// standard logrus logger, since concurrently running jobs each add and
+// remove their own hook from it.
+var logHooksMu sync.Mutex
+
+func addLogHook(hook log.Hook) {
+	logHooksMu.Lock()
+	defer logHooksMu.Unlock()
+	log.AddHook(hook)
+}
+
+func removeLogHook(hook log.Hook) {
+	logHooksMu.Lock()
+	defer logHooksMu.Unlock()
+	hooks := log.StandardLogger().Hooks
+	for _, level := range hook.Levels() {
+		levelHooks := hooks[level]
+		for i, h := range levelHooks {
+			if h == hook {
+				hooks[level] = append(levelHooks[:i], levelHooks[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (r *RemoteRunner) sleepBackoff(ctx context.Context) bool {
+	backoff := r.config.Backoff
+	if backoff <= 0 {
+		backoff = 5 * time.Second
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(backoff):
+		return true
+	}
+}
+
+type agentTokenContextKey struct{}
+
+func withAgentToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, agentTokenContextKey{}, token)
+}
+
+// AgentTokenFromContext returns the Config.AgentToken a RemoteRunner
+// attached to ctx, for CoordinatorClient implementations to use when
+// authenticating outbound calls.
+func AgentTokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(agentTokenContextKey{}).(string)
+	return token, ok
+}
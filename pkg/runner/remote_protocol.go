@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"context"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+// Filter describes the jobs an agent is willing to accept from the
+// coordinator, analogous to a Drone/Woodpecker agent's capability filter.
+type Filter struct {
+	Labels   []string `json:"labels,omitempty"`
+	Platform string   `json:"platform,omitempty"`
+}
+
+// Job is a unit of work handed out by the coordinator in response to Next.
+// Plan is the already-resolved workflow plan for the job; the agent runs it
+// locally with the existing NewPlanExecutor.
+type Job struct {
+	ID    string      `json:"id"`
+	Plan  *model.Plan `json:"plan"`
+	Token string      `json:"token,omitempty"`
+}
+
+// State reports the lifecycle of a job back to the coordinator.
+type State struct {
+	JobID    string `json:"job_id"`
+	Result   string `json:"result"` // "success", "failure", "cancelled"
+	ExitCode int    `json:"exit_code,omitempty"`
+}
+
+// Line is one line of step log output streamed back to the coordinator.
+type Line struct {
+	JobID  string `json:"job_id"`
+	StepID string `json:"step_id"`
+	Line   string `json:"line"`
+}
+
+// CoordinatorClient is the transport-agnostic interface RemoteRunner drives
+// its poll loop through. Implementations carry the actual WebSocket/gRPC
+// channel to the coordinator; third parties (Gitea act_runner in particular)
+// implement the server side of this protocol.
+type CoordinatorClient interface {
+	// Next blocks until a job matching filter is available, ctx is
+	// cancelled, or an error occurs.
+	Next(ctx context.Context, filter Filter) (*Job, error)
+	// Log streams a single line of step output for jobID.
+	Log(ctx context.Context, jobID string, line Line) error
+	// Update reports the current state of a job.
+	Update(ctx context.Context, state State) error
+	// Extend renews the lease held on jobID so the coordinator doesn't
+	// consider it abandoned.
+	Extend(ctx context.Context, jobID string) error
+	// Wait returns a channel that is closed when the coordinator cancels
+	// jobID (e.g. a user requested cancellation mid-run).
+	Wait(ctx context.Context, jobID string) (<-chan struct{}, error)
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type fakeCoordinatorClient struct {
+	mu    sync.Mutex
+	lines []Line
+}
+
+func (f *fakeCoordinatorClient) Next(ctx context.Context, filter Filter) (*Job, error) {
+	return nil, nil
+}
+
+func (f *fakeCoordinatorClient) Update(ctx context.Context, state State) error { return nil }
+
+func (f *fakeCoordinatorClient) Extend(ctx context.Context, jobID string) error { return nil }
+
+func (f *fakeCoordinatorClient) Wait(ctx context.Context, jobID string) (<-chan struct{}, error) {
+	return nil, nil
+}
+
+func (f *fakeCoordinatorClient) Log(ctx context.Context, jobID string, line Line) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lines = append(f.lines, line)
+	return nil
+}
+
+// TestRemoteLogHookForwardsTaggedEntries drives a real entry through the
+// process-wide logrus logger that addLogHook/remoteLogHook attach to in
+// production (rather than a throwaway logger instance), and checks that
+// only the entry tagged for this hook's jobID reaches the fake
+// CoordinatorClient, with the right jobID/stepID/line.
+//
+// This does NOT cover WithJobLogger itself, since it isn't present in this
+// tree: it's the production code's responsibility to tag entries through
+// log.WithFields(log.Fields{"jobID": ..., "stepID": ...}) on the same
+// logrus.StandardLogger() instance, and a regression there (e.g. attaching
+// a dedicated/child logger instead) would not be caught by this test.
+func TestRemoteLogHookForwardsTaggedEntries(t *testing.T) {
+	client := &fakeCoordinatorClient{}
+	hook := &remoteLogHook{ctx: context.Background(), client: client, jobID: "job-1"}
+	addLogHook(hook)
+	defer removeLogHook(hook)
+
+	log.WithFields(log.Fields{"jobID": "job-1", "stepID": "step-1"}).Info("hello from job-1")
+	log.WithFields(log.Fields{"jobID": "job-2", "stepID": "step-1"}).Info("hello from job-2")
+	log.Info("untagged entry")
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(client.lines) != 1 {
+		t.Fatalf("got %d forwarded lines, want 1: %+v", len(client.lines), client.lines)
+	}
+	got := client.lines[0]
+	if got.JobID != "job-1" || got.StepID != "step-1" || got.Line != "hello from job-1" {
+		t.Errorf("forwarded line = %+v, want {job-1 step-1 hello from job-1}", got)
+	}
+}
+
+// TestRemoveLogHookDetaches checks that removeLogHook actually stops further
+// delivery, since logrus has no built-in hook-removal API and this package
+// manipulates log.StandardLogger().Hooks directly to implement it.
+func TestRemoveLogHookDetaches(t *testing.T) {
+	client := &fakeCoordinatorClient{}
+	hook := &remoteLogHook{ctx: context.Background(), client: client, jobID: "job-1"}
+	addLogHook(hook)
+	removeLogHook(hook)
+
+	log.WithFields(log.Fields{"jobID": "job-1", "stepID": "step-1"}).Info("should not be forwarded")
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(client.lines) != 0 {
+		t.Errorf("got %d forwarded lines after removeLogHook, want 0: %+v", len(client.lines), client.lines)
+	}
+}
@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRetryMatchesKind(t *testing.T) {
+	kases := []struct {
+		name   string
+		on     []string
+		kind   string
+		result bool
+	}{
+		{"empty on list retries anything", nil, "failure", true},
+		{"empty on list retries timeout too", []string{}, "timeout", true},
+		{"matching kind is retried", []string{"failure"}, "failure", true},
+		{"non-matching kind is not retried", []string{"timeout"}, "failure", false},
+		{"any of several kinds matches", []string{"timeout", "failure"}, "failure", true},
+	}
+	for _, kase := range kases {
+		t.Run(kase.name, func(t *testing.T) {
+			if got := retryMatchesKind(kase.on, kase.kind); got != kase.result {
+				t.Errorf("retryMatchesKind(%v, %q) = %v, want %v", kase.on, kase.kind, got, kase.result)
+			}
+		})
+	}
+}
+
+func TestFormatAttemptName(t *testing.T) {
+	kases := []struct {
+		name    string
+		attempt int
+		result  string
+	}{
+		{"first attempt is unchanged", 0, "build"},
+		{"second attempt is suffixed with the 1-indexed number", 1, "build (attempt 2)"},
+		{"third attempt", 2, "build (attempt 3)"},
+	}
+	for _, kase := range kases {
+		t.Run(kase.name, func(t *testing.T) {
+			if got := formatAttemptName("build", kase.attempt); got != kase.result {
+				t.Errorf("formatAttemptName(%q, %d) = %q, want %q", "build", kase.attempt, got, kase.result)
+			}
+		})
+	}
+}
+
+func TestTransitiveDependents(t *testing.T) {
+	kases := []struct {
+		name     string
+		neededBy map[string][]string
+		startID  string
+		result   []string
+	}{
+		{"no dependents", map[string][]string{}, "build", nil},
+		{
+			"direct dependent only",
+			map[string][]string{"build": {"test"}},
+			"build",
+			[]string{"test"},
+		},
+		{
+			"multi-level chain",
+			map[string][]string{"build": {"test"}, "test": {"deploy"}},
+			"build",
+			[]string{"test", "deploy"},
+		},
+		{
+			"diamond dependency is only visited once",
+			map[string][]string{"build": {"test", "lint"}, "test": {"deploy"}, "lint": {"deploy"}},
+			"build",
+			[]string{"test", "deploy", "lint"},
+		},
+		{
+			"unrelated jobs are excluded",
+			map[string][]string{"build": {"test"}, "other": {"other-dependent"}},
+			"build",
+			[]string{"test"},
+		},
+	}
+	for _, kase := range kases {
+		t.Run(kase.name, func(t *testing.T) {
+			got := transitiveDependents(kase.neededBy, kase.startID)
+			if !reflect.DeepEqual(got, kase.result) {
+				t.Errorf("transitiveDependents(%v, %q) = %v, want %v", kase.neededBy, kase.startID, got, kase.result)
+			}
+		})
+	}
+}
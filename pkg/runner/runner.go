@@ -3,6 +3,7 @@ package runner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -18,6 +19,11 @@ import (
 // Runner provides capabilities to run GitHub actions
 type Runner interface {
 	NewPlanExecutor(plan *model.Plan) common.Executor
+
+	// Restart re-drives an already-run plan for a single job, clearing its
+	// prior result (and that of everything that transitively needs it) and
+	// re-enqueuing them, analogous to a coordinator-triggered rebuild.
+	Restart(plan *model.Plan, jobID string) common.Executor
 }
 
 // Config contains the config for a new runner
@@ -58,15 +64,38 @@ type Config struct {
 	ReplaceGheActionTokenWithGithubCom string                     // Token of private action repo on GitHub.
 	Matrix                             map[string]map[string]bool // Matrix config to run
 
-	PresetGitHubContext   *model.GithubContext         // the preset github context, overrides some fields like DefaultBranch, Env, Secrets etc.
-	EventJSON             string                       // the content of JSON file to use for event.json in containers, overrides EventPath
-	ContainerNamePrefix   string                       // the prefix of container name
-	ContainerMaxLifetime  time.Duration                // the max lifetime of job containers
-	ContainerNetworkMode  string                       // the network mode of job containers
-	DefaultActionInstance string                       // the default actions web site
-	PlatformPicker        func(labels []string) string // platform picker, it will take precedence over Platforms if isn't nil
-	JobLoggerLevel        *log.Level                   // the level of job logger
-	Vars                  map[string]string            // the list of variables set at the repository, environment, or organization levels.
+	PresetGitHubContext            *model.GithubContext         // the preset github context, overrides some fields like DefaultBranch, Env, Secrets etc.
+	EventJSON                      string                       // the content of JSON file to use for event.json in containers, overrides EventPath
+	ContainerNamePrefix            string                       // the prefix of container name
+	ContainerMaxLifetime           time.Duration                // the max lifetime of job containers
+	ContainerNetworkMode           string                       // the network mode of job containers
+	ContainerNetworkDriver         string                       // the driver used when creating a user-defined network, e.g. "overlay", "macvlan", "ipvlan"; defaults to "bridge"
+	ContainerNetworkScope          string                       // the scope of the created network, e.g. "local", "swarm"; defaults to "local"
+	ContainerNetworkOptions        map[string]string            // driver-specific options for the created network (e.g. com.docker.network.driver.mtu, parent)
+	ContainerNetworkIPAMSubnet     string                       // IPAM subnet CIDR for the created network
+	ContainerNetworkIPAMGateway    string                       // IPAM gateway for the created network
+	ContainerNetworkIPAMIPRange    string                       // IPAM IP range for the created network
+	ContainerNetworkIPAMAuxAddress map[string]string            // IPAM auxiliary addresses for the created network
+	ContainerNetworkAttachable     bool                         // allow standalone containers to attach to the created network
+	ContainerNetworkInternal       bool                         // restrict external access to the created network
+	ContainerNetworkEnableIPv6     bool                         // enable IPv6 on the created network
+	DefaultActionInstance          string                       // the default actions web site
+	PlatformPicker                 func(labels []string) string // platform picker, it will take precedence over Platforms if isn't nil
+	JobLoggerLevel                 *log.Level                   // the level of job logger
+	Vars                           map[string]string            // the list of variables set at the repository, environment, or organization levels.
+
+	CoordinatorURL                 string                       // URL of the coordinator a RemoteRunner polls for jobs
+	AgentToken                     string                       // token used to authenticate this agent with the coordinator
+	AgentLabels                    []string                     // labels advertised to the coordinator when polling for jobs
+	MaxProcs                       int                          // maximum number of jobs this process runs concurrently, across all stages and matrix fan-out; 0 means unlimited for local runs, while a RemoteRunner then pulls one job at a time
+	Backoff                        time.Duration                // delay before retrying a failed coordinator RPC
+
+	RetryLimit                     int                          // number of times to retry a failed job before giving up; 0 disables retries
+	RetryBackoff                   time.Duration                // delay before the first retry, doubling on each subsequent attempt; default 15s
+	RetryOn                        []string                     // failure kinds ("failure", "timeout") to retry when set; empty retries on any failure kind
+
+	MaxParallelStages              int                          // max number of stage pipelines to run in parallel; 0 = auto (container engine NCPU)
+	MaxParallelMatrix              int                          // max number of matrix jobs to run in parallel per job, when the job itself has no strategy.max-parallel; 0 = auto (4)
 }
 
 // GetToken: Adapt to Gitea
@@ -95,6 +124,32 @@ func (c Config) IsNetworkModeContainer() bool {
 	return len(parts) > 1 && parts[0] == "container"
 }
 
+// NetworkCreateOptions builds the options used to create the user-defined
+// network named by ContainerNetworkMode, honoring the driver, IPAM and
+// scope fields set on the Config.
+func (c Config) NetworkCreateOptions(inheritDriverOpts []string) container.NetworkCreateOptions {
+	opts := container.NetworkCreateOptions{
+		Driver:            c.ContainerNetworkDriver,
+		Scope:             c.ContainerNetworkScope,
+		Options:           c.ContainerNetworkOptions,
+		Attachable:        c.ContainerNetworkAttachable,
+		Internal:          c.ContainerNetworkInternal,
+		EnableIPv6:        c.ContainerNetworkEnableIPv6,
+		InheritDriverOpts: inheritDriverOpts,
+	}
+
+	if c.ContainerNetworkIPAMSubnet != "" || c.ContainerNetworkIPAMGateway != "" || c.ContainerNetworkIPAMIPRange != "" {
+		opts.IPAM = &container.NetworkCreateIPAMConfig{
+			Subnet:     c.ContainerNetworkIPAMSubnet,
+			Gateway:    c.ContainerNetworkIPAMGateway,
+			IPRange:    c.ContainerNetworkIPAMIPRange,
+			AuxAddress: c.ContainerNetworkIPAMAuxAddress,
+		}
+	}
+
+	return opts
+}
+
 func (c Config) IsNetworkUserDefined() bool {
 	return !c.IsNetworkModeHost() && !c.IsNetworkModeNone() && !c.IsNetworkModeBridge() && !c.IsNetworkModeContainer()
 }
@@ -106,7 +161,8 @@ type caller struct {
 type runnerImpl struct {
 	config    *Config
 	eventJSON string
-	caller    *caller // the job calling this runner (caller of a reusable workflow)
+	caller    *caller       // the job calling this runner (caller of a reusable workflow)
+	procSem   chan struct{} // global semaphore capping concurrent jobs at Config.MaxProcs across all stages/plans
 }
 
 // New Creates a new Runner
@@ -114,6 +170,9 @@ func New(runnerConfig *Config) (Runner, error) {
 	runner := &runnerImpl{
 		config: runnerConfig,
 	}
+	if runnerConfig.MaxProcs > 0 {
+		runner.procSem = make(chan struct{}, runnerConfig.MaxProcs)
+	}
 
 	return runner.configure()
 }
@@ -144,40 +203,48 @@ func (runner *runnerImpl) configure() (Runner, error) {
 
 // NewPlanExecutor ...
 func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
+	return runner.newPlanExecutor(plan, nil)
+}
+
+// newPlanExecutor builds the executor for plan. When only is non-nil, runs
+// whose JobID isn't in it are skipped entirely instead of being
+// re-executed, so Restart can re-drive a single job (and its dependents)
+// without rerunning everything else in the plan.
+func (runner *runnerImpl) newPlanExecutor(plan *model.Plan, only map[string]bool) common.Executor {
 	maxJobNameLen := 0
 
 	stagePipeline := make([]common.Executor, 0)
 	for i := range plan.Stages {
 		stage := plan.Stages[i]
+		stageNum := i
 		stagePipeline = append(stagePipeline, func(ctx context.Context) error {
 			pipeline := make([]common.Executor, 0)
 			for _, run := range stage.Runs {
+				if only != nil && !only[run.JobID] {
+					continue
+				}
 				stageExecutor := make([]common.Executor, 0)
 				job := run.Job()
 
 				if job.Strategy != nil {
 					strategyRc := runner.newRunContext(ctx, run, nil)
 					if err := strategyRc.NewExpressionEvaluator(ctx).EvaluateYamlNode(ctx, &job.Strategy.RawMatrix); err != nil {
-						log.Errorf("Error while evaluating matrix: %v", err)
+						return &common.RunError{Code: common.MatrixInvalid, JobID: run.JobID, Stage: stageNum, Cause: err}
 					}
 				}
 
-				var matrixes []map[string]interface{}
-				if m, err := job.GetMatrixes(); err != nil {
-					log.Errorf("Error while get job's matrix: %v", err)
-				} else {
-					matrixes = selectMatrixes(m, runner.config.Matrix)
+				m, err := job.GetMatrixes()
+				if err != nil {
+					return &common.RunError{Code: common.MatrixInvalid, JobID: run.JobID, Stage: stageNum, Cause: err}
 				}
+				matrixes := selectMatrixes(m, runner.config.Matrix)
 				log.Debugf("Final matrix after applying user inclusions '%v'", matrixes)
 
-				maxParallel := 4
+				strategyMaxParallel := 0
 				if job.Strategy != nil {
-					maxParallel = job.Strategy.MaxParallel
-				}
-
-				if len(matrixes) < maxParallel {
-					maxParallel = len(matrixes)
+					strategyMaxParallel = job.Strategy.MaxParallel
 				}
+				maxParallel := resolveMaxParallel(runner.config.MaxParallelMatrix, strategyMaxParallel, len(matrixes))
 
 				for i, matrix := range matrixes {
 					matrix := matrix
@@ -191,19 +258,23 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 					}
 					stageExecutor = append(stageExecutor, func(ctx context.Context) error {
 						jobName := fmt.Sprintf("%-*s", maxJobNameLen, rc.String())
-						return rc.Executor()(common.WithJobErrorContainer(WithJobLogger(ctx, rc.Run.JobID, jobName, rc.Config, &rc.Masks, matrix)))
+						return runner.executeWithGlobalLimit(ctx, rc, jobName, matrix, stageNum)
 					})
 				}
 				pipeline = append(pipeline, common.NewParallelExecutor(maxParallel, stageExecutor...))
 			}
-			var ncpu int
-			info, err := container.GetHostInfo(ctx)
-			if err != nil {
-				log.Errorf("failed to obtain container engine info: %s", err)
-				ncpu = 1 // sane default?
-			} else {
-				ncpu = info.NCPU
+
+			ncpu := runner.config.MaxParallelStages
+			if ncpu <= 0 {
+				info, err := container.GetHostInfo(ctx)
+				if err != nil {
+					log.Errorf("failed to obtain container engine info: %s", err)
+					ncpu = 1 // sane default?
+				} else {
+					ncpu = info.NCPU
+				}
 			}
+			log.Debugf("Effective concurrency: MaxParallelStages=%d MaxParallelMatrix=%d MaxProcs=%d", ncpu, runner.config.MaxParallelMatrix, runner.config.MaxProcs)
 			return common.NewParallelExecutor(ncpu, pipeline...)(ctx)
 		})
 	}
@@ -211,12 +282,167 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 	return common.NewPipelineExecutor(stagePipeline...).Then(handleFailure(plan))
 }
 
-func handleFailure(plan *model.Plan) common.Executor {
+// executeWithGlobalLimit acquires the process-wide Config.MaxProcs semaphore,
+// if one is configured, before running rc through executeWithRetry. This
+// caps the number of jobs running concurrently across every stage and
+// matrix fan-out, and across every plan handed to this runnerImpl (e.g.
+// successive jobs pulled by a RemoteRunner), regardless of the per-stage
+// and per-matrix parallelism limits.
+func (runner *runnerImpl) executeWithGlobalLimit(ctx context.Context, rc *RunContext, jobName string, matrix map[string]interface{}, stageNum int) error {
+	if runner.procSem != nil {
+		select {
+		case runner.procSem <- struct{}{}:
+			defer func() { <-runner.procSem }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return runner.executeWithRetry(ctx, rc, jobName, matrix, stageNum)
+}
+
+// executeWithRetry runs rc.Executor(), retrying with exponential backoff
+// when the job reports a "failure" result. The retry budget, backoff, and
+// the failure kinds ("failure", "timeout") worth retrying are process-wide,
+// from Config.RetryLimit/Config.RetryBackoff/Config.RetryOn — there is no
+// per-job override, since that would require a `strategy.retry` field on
+// model.JobStrategy that doesn't exist in this tree. StepResults and Masks
+// are reset between attempts so each retry starts clean, and the job
+// logger is re-tagged with the attempt number.
+func (runner *runnerImpl) executeWithRetry(ctx context.Context, rc *RunContext, jobName string, matrix map[string]interface{}, stageNum int) error {
+	retryLimit := runner.config.RetryLimit
+	backoff := runner.config.RetryBackoff
+	on := runner.config.RetryOn
+	if backoff <= 0 {
+		backoff = 15 * time.Second
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		execErr := rc.Executor()(common.WithJobErrorContainer(WithJobLogger(ctx, rc.Run.JobID, formatAttemptName(jobName, attempt), rc.Config, &rc.Masks, matrix)))
+		if execErr == nil && rc.Run.Job().Result != "failure" {
+			return nil
+		}
+
+		kind := "failure"
+		if errors.Is(execErr, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			kind = "timeout"
+		}
+		if execErr == nil {
+			execErr = fmt.Errorf("job '%s' failed", rc.String())
+		}
+		err = &common.RunError{Code: common.JobFailed, JobID: rc.Run.JobID, Stage: stageNum, Attempt: attempt, Cause: execErr}
+
+		if !retryMatchesKind(on, kind) || attempt >= retryLimit || !common.IsRetryable(err) {
+			return err
+		}
+
+		log.Debugf("Job '%s' failed (%s), retrying in %s (attempt %d/%d)", rc.String(), kind, backoff, attempt+1, retryLimit)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		rc.StepResults = make(map[string]*model.StepResult)
+		rc.Masks = nil
+		rc.Run.Job().Result = ""
+	}
+}
+
+// retryMatchesKind reports whether a failure of the given kind ("failure" or
+// "timeout") should be retried. An empty on list means "retry any failure",
+// matching Config.RetryOn's default behavior when it isn't set.
+func retryMatchesKind(on []string, kind string) bool {
+	if len(on) == 0 {
+		return true
+	}
+	for _, k := range on {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// formatAttemptName returns the job name executeWithRetry tags the logger
+// context with for a given (0-indexed) attempt: unchanged on the first
+// attempt, suffixed with the human (1-indexed) attempt number afterward.
+func formatAttemptName(jobName string, attempt int) string {
+	if attempt == 0 {
+		return jobName
+	}
+	return fmt.Sprintf("%s (attempt %d)", jobName, attempt+1)
+}
+
+// Restart re-runs only the job identified by jobID within plan, along with
+// every job that transitively needs it: their prior Result is cleared and
+// every other run in the plan is left untouched and skipped, so this is a
+// single-job rebuild rather than a re-run of the whole plan.
+func (runner *runnerImpl) Restart(plan *model.Plan, jobID string) common.Executor {
 	return func(ctx context.Context) error {
+		toReset := map[string]bool{jobID: true}
+		for _, id := range dependentJobIDs(plan, jobID) {
+			toReset[id] = true
+		}
+
 		for _, stage := range plan.Stages {
+			for _, run := range stage.Runs {
+				if toReset[run.JobID] {
+					run.Job().Result = ""
+				}
+			}
+		}
+
+		return runner.newPlanExecutor(plan, toReset)(ctx)
+	}
+}
+
+// dependentJobIDs returns every job in plan that transitively needs jobID,
+// so Restart can clear their prior results alongside the job being re-run.
+func dependentJobIDs(plan *model.Plan, jobID string) []string {
+	neededBy := map[string][]string{}
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			for _, need := range run.Job().Needs() {
+				neededBy[need] = append(neededBy[need], run.JobID)
+			}
+		}
+	}
+	return transitiveDependents(neededBy, jobID)
+}
+
+// transitiveDependents walks neededBy (a job id -> ids of jobs that directly
+// need it) outward from startID and returns every id transitively reachable,
+// so dependentJobIDs's graph walk can be unit tested without a model.Plan.
+func transitiveDependents(neededBy map[string][]string, startID string) []string {
+	var dependents []string
+	seen := map[string]bool{}
+	var visit func(id string)
+	visit = func(id string) {
+		for _, dependent := range neededBy[id] {
+			if !seen[dependent] {
+				seen[dependent] = true
+				dependents = append(dependents, dependent)
+				visit(dependent)
+			}
+		}
+	}
+	visit(startID)
+	return dependents
+}
+
+func handleFailure(plan *model.Plan) common.Executor {
+	return func(ctx context.Context) error {
+		for i, stage := range plan.Stages {
 			for _, run := range stage.Runs {
 				if run.Job().Result == "failure" {
-					return fmt.Errorf("Job '%s' failed", run.String())
+					return &common.RunError{
+						Code:  common.JobFailed,
+						JobID: run.JobID,
+						Stage: i,
+						Cause: fmt.Errorf("job '%s' failed", run.String()),
+					}
 				}
 			}
 		}
@@ -224,6 +450,28 @@ func handleFailure(plan *model.Plan) common.Executor {
 	}
 }
 
+// resolveMaxParallel computes how many matrix jobs of a single job definition
+// may run concurrently. configuredMaxParallel is Config.MaxParallelMatrix
+// (0 means "use the built-in default of 4"); strategyMaxParallel is the
+// job's own `strategy.max-parallel` (0 means "the job didn't set one", so
+// it doesn't override configuredMaxParallel). The result is never greater
+// than matrixCount, since there's no point reserving more concurrency than
+// there are matrix jobs to run.
+func resolveMaxParallel(configuredMaxParallel, strategyMaxParallel, matrixCount int) int {
+	maxParallel := 4
+	if configuredMaxParallel > 0 {
+		maxParallel = configuredMaxParallel
+	}
+	if strategyMaxParallel > 0 {
+		maxParallel = strategyMaxParallel
+	}
+
+	if matrixCount < maxParallel {
+		maxParallel = matrixCount
+	}
+	return maxParallel
+}
+
 func selectMatrixes(originalMatrixes []map[string]interface{}, targetMatrixValues map[string]map[string]bool) []map[string]interface{} {
 	matrixes := make([]map[string]interface{}, 0)
 	for _, original := range originalMatrixes {